@@ -2,15 +2,22 @@ package mustache
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 type varElement struct {
-	name string
-	raw  bool
+	name      string
+	raw       bool
+	formatter string
+	arg       string
+	line      int
 }
 
 type sectionElement struct {
@@ -18,17 +25,143 @@ type sectionElement struct {
 	inverted  bool
 	startline int
 	elems     []interface{}
+	raw       string
+	// otag/ctag are the delimiters in effect when the section's body began parsing, used to
+	// re-parse a lambda section's raw text (or a two-arg lambda's render callback) with the right
+	// delimiters instead of always assuming the default "{{"/"}}".
+	otag string
+	ctag string
+}
+
+// partialElement is emitted for a "{{>name}}" tag. indent holds the leading whitespace of the
+// tag's line when the partial is standalone (alone on its line), per the spec's partial
+// indentation rule; it is prepended to every line the partial renders.
+type partialElement struct {
+	name   string
+	indent string
+	line   int
 }
 
 type Template struct {
-	data    string
-	otag    string
-	ctag    string
-	p       int
-	curline int
-	elems   []interface{}
+	data       string
+	otag       string
+	ctag       string
+	p          int
+	curline    int
+	elems      []interface{}
+	formatters FormatterMap
+	loader     Loader
+	// Strict, when true, turns variable and (non-inverted) section lookup misses, and panics
+	// recovered while rendering a variable, into a returned *RenderError instead of silently
+	// rendering nothing. It has no effect on Render, which has no error to return; use RenderTo
+	// to observe it.
+	Strict bool
+}
+
+// renderContext carries the cross-cutting state threaded through a single render pass:
+// registered formatters, the partial loader (if any), the current partial-expansion depth used
+// to bound recursive partials, whether lookup misses are reported as errors, and the otag/ctag
+// delimiters in effect at the current point in the template, used to re-parse a lambda's
+// returned text with the right delimiters instead of always assuming the default "{{"/"}}".
+type renderContext struct {
+	formatters FormatterMap
+	loader     Loader
+	depth      int
+	strict     bool
+	otag       string
+	ctag       string
+}
+
+const maxPartialDepth = 100
+
+// Loader loads a named partial template for "{{>name}}" tags. Implementations should cache
+// parsed templates so a partial referenced many times is only parsed once.
+type Loader interface {
+	Load(name string) (*Template, error)
+}
+
+type fileSystemLoader struct {
+	dir string
+	ext string
+
+	mu    sync.Mutex
+	cache map[string]*Template
+}
+
+// FileSystemLoader returns a Loader that loads partial "name" from the file
+// filepath.Join(dir, name+ext).
+func FileSystemLoader(dir string, ext string) Loader {
+	return &fileSystemLoader{dir: dir, ext: ext, cache: map[string]*Template{}}
+}
+
+func (l *fileSystemLoader) Load(name string) (*Template, error) {
+	if strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("mustache: invalid partial name %q", name)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if tmpl, ok := l.cache[name]; ok {
+		return tmpl, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(l.dir, name+l.ext))
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := ParseString(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	l.cache[name] = tmpl
+	return tmpl, nil
 }
 
+type stringMapLoader struct {
+	templates map[string]string
+
+	mu    sync.Mutex
+	cache map[string]*Template
+}
+
+// StringMapLoader returns a Loader that loads partial "name" from templates[name].
+func StringMapLoader(templates map[string]string) Loader {
+	return &stringMapLoader{templates: templates, cache: map[string]*Template{}}
+}
+
+func (l *stringMapLoader) Load(name string) (*Template, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if tmpl, ok := l.cache[name]; ok {
+		return tmpl, nil
+	}
+
+	data, ok := l.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("mustache: no partial named %q", name)
+	}
+
+	tmpl, err := ParseString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	l.cache[name] = tmpl
+	return tmpl, nil
+}
+
+// FormatterMap maps a formatter name referenced by a "{{name|fmt}}" or "{{name|fmt arg}}" tag to
+// a function that writes the looked-up value to w, optionally parameterized by arg. It mirrors
+// the FormatterMap concept from Go's original text/template (old/template) package. When a tag
+// names a formatter that isn't registered, rendering falls back to the default HTML-escaped
+// output. Only "{{...}}" variable tags take a formatter; a "{{{...}}}" or "{{&...}}" tag looks up
+// its whole body as the name and always writes the value raw.
+type FormatterMap map[string]func(w io.Writer, value interface{}, arg string)
+
 type parseError struct {
 	line    int
 	message string
@@ -36,6 +169,31 @@ type parseError struct {
 
 func (p parseError) Error() string { return fmt.Sprintf("line %d: %s", p.line, p.message) }
 
+// RenderError reports a failure encountered while rendering a specific tag: a write error from
+// the underlying io.Writer while writing a variable or lambda section's output, or, in
+// Template.Strict mode only, a lookup miss, an error returned by a looked-up method, a panic
+// recovered during lookup/formatting (e.g. a bad map key type), or a partial that can't be
+// loaded. Line and Tag identify where rendering stopped.
+type RenderError struct {
+	Line  int
+	Tag   string
+	Cause error
+}
+
+func (e *RenderError) Error() string {
+	return fmt.Sprintf("mustache: line %d: tag %q: %s", e.Line, e.Tag, e.Cause)
+}
+
+func (e *RenderError) Unwrap() error { return e.Cause }
+
+// errNoSuchKey is the RenderError.Cause used in Template.Strict mode when a variable or
+// non-inverted section tag's name doesn't resolve anywhere in the context chain.
+var errNoSuchKey = errors.New("no such key")
+
+// errNoLoader is the RenderError.Cause used in Template.Strict mode when a "{{>name}}" tag is
+// rendered without a Loader configured.
+var errNoLoader = errors.New("no partial loader configured")
+
 var (
 	esc_quot = []byte("&quot;")
 	esc_apos = []byte("&apos;")
@@ -45,7 +203,7 @@ var (
 )
 
 // taken from pkg/template
-func htmlEscape(w io.Writer, s []byte) {
+func htmlEscape(w io.Writer, s []byte) error {
 	var esc []byte
 	last := 0
 	for i, c := range s {
@@ -63,11 +221,16 @@ func htmlEscape(w io.Writer, s []byte) {
 		default:
 			continue
 		}
-		w.Write(s[last:i])
-		w.Write(esc)
+		if _, err := w.Write(s[last:i]); err != nil {
+			return err
+		}
+		if _, err := w.Write(esc); err != nil {
+			return err
+		}
 		last = i + 1
 	}
-	w.Write(s[last:])
+	_, err := w.Write(s[last:])
+	return err
 }
 
 func (tmpl *Template) readString(s string) (string, error) {
@@ -112,8 +275,103 @@ func (tmpl *Template) readString(s string) (string, error) {
 	return "", nil
 }
 
+// parseVarTag splits a variable tag body on "|" to pull out an optional formatter name and
+// argument, e.g. "name|fmt arg" yields name "name", formatter "fmt", arg "arg".
+func parseVarTag(tag string, raw bool, line int) *varElement {
+	name := tag
+	formatter := ""
+	arg := ""
+	if idx := strings.Index(tag, "|"); idx >= 0 {
+		name = strings.TrimSpace(tag[:idx])
+		rest := strings.TrimSpace(tag[idx+1:])
+		if sp := strings.IndexAny(rest, " \t"); sp >= 0 {
+			formatter = rest[:sp]
+			arg = strings.TrimSpace(rest[sp+1:])
+		} else {
+			formatter = rest
+		}
+	}
+	return &varElement{name, raw, formatter, arg, line}
+}
+
+// standaloneIndent reports whether the tag starting at openTagStart is alone on its line (only
+// preceded by spaces/tabs since the previous newline), returning that leading whitespace.
+func standaloneIndent(data string, openTagStart int) (indent string, standalone bool) {
+	i := openTagStart
+	for i > 0 && (data[i-1] == ' ' || data[i-1] == '\t') {
+		i--
+	}
+	if i > 0 && data[i-1] != '\n' {
+		return "", false
+	}
+	return data[i:openTagStart], true
+}
+
+// standaloneEnd reports whether the content starting at p (the position right after a tag's
+// closing delimiter) is only spaces/tabs up to the next newline (or end of input), returning the
+// position just past that newline so it can be swallowed.
+func standaloneEnd(data string, p int) (end int, standalone bool) {
+	i := p
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t') {
+		i++
+	}
+	if i >= len(data) {
+		return i, true
+	}
+	if data[i] == '\n' {
+		return i + 1, true
+	}
+	if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+		return i + 2, true
+	}
+	return p, false
+}
+
+// parseSetDelimiterTag parses the body of a "{{=<% %>=}}" tag (tag is the trimmed text between
+// the tag's own delimiters, e.g. "=<% %>="), returning the new open and close delimiters.
+func parseSetDelimiterTag(tag string) (otag, ctag string, err error) {
+	if len(tag) < 2 || tag[0] != '=' || tag[len(tag)-1] != '=' {
+		return "", "", errors.New("invalid set-delimiter tag")
+	}
+	parts := strings.Fields(tag[1 : len(tag)-1])
+	if len(parts) != 2 {
+		return "", "", errors.New("invalid set-delimiter tag")
+	}
+	return parts[0], parts[1], nil
+}
+
+// stripStandaloneLine implements the Mustache spec's standalone-line rule: if the tag starting at
+// openTagStart is alone on its line (only whitespace before and after it), the already-buffered
+// leading indentation is removed from elems (the tmpl.elems or section.elems the tag's preceding
+// text was appended to) and the line's trailing whitespace and newline are swallowed from the
+// input, so the tag contributes no blank line to the rendered output. It reports the leading
+// indentation and whether the tag was in fact standalone; tags used for value output (variables,
+// plain text) never call this, only structural tags like sections (including ones whose value
+// later turns out to be a lambda), comments, partials, and set-delimiter tags.
+func (tmpl *Template) stripStandaloneLine(elems []interface{}, openTagStart int) (indent string, standalone bool) {
+	indent, ok := standaloneIndent(tmpl.data, openTagStart)
+	if !ok {
+		return "", false
+	}
+	end, ok := standaloneEnd(tmpl.data, tmpl.p)
+	if !ok {
+		return "", false
+	}
+
+	if len(indent) > 0 {
+		last := len(elems) - 1
+		if s, ok := elems[last].(string); ok {
+			elems[last] = s[:len(s)-len(indent)]
+		}
+	}
+	tmpl.p = end
+	return indent, true
+}
+
 func (tmpl *Template) parseSection(section *sectionElement) error {
+	rawStart := tmpl.p
 	for {
+		tagStart := tmpl.p
 		text, err := tmpl.readString(tmpl.otag)
 
 		if err == io.EOF {
@@ -122,6 +380,7 @@ func (tmpl *Template) parseSection(section *sectionElement) error {
 
 		// put text into an item
 		text = text[0 : len(text)-len(tmpl.otag)]
+		openTagStart := tagStart + len(text)
 		section.elems = append(section.elems, text)
 		if tmpl.p < len(tmpl.data) && tmpl.data[tmpl.p] == '{' {
 			text, err = tmpl.readString("}" + tmpl.ctag)
@@ -143,41 +402,54 @@ func (tmpl *Template) parseSection(section *sectionElement) error {
 		switch tag[0] {
 		case '!':
 			//ignore comment
-			break
+			tmpl.stripStandaloneLine(section.elems, openTagStart)
 		case '#', '^':
 			name := strings.TrimSpace(tag[1:])
+			tmpl.stripStandaloneLine(section.elems, openTagStart)
 
-			//ignore the newline when a section starts
-			if len(tmpl.data) > tmpl.p && tmpl.data[tmpl.p] == '\n' {
-				tmpl.p += 1
-			} else if len(tmpl.data) > tmpl.p+1 && tmpl.data[tmpl.p] == '\r' && tmpl.data[tmpl.p+1] == '\n' {
-				tmpl.p += 2
-			}
-
-			se := sectionElement{name, tag[0] == '^', tmpl.curline, []interface{}{}}
+			savedOtag, savedCtag := tmpl.otag, tmpl.ctag
+			se := sectionElement{name, tag[0] == '^', tmpl.curline, []interface{}{}, "", tmpl.otag, tmpl.ctag}
 			err := tmpl.parseSection(&se)
 			if err != nil {
 				return err
 			}
+			// Delimiter changes made inside a section are scoped to that section.
+			tmpl.otag, tmpl.ctag = savedOtag, savedCtag
 			section.elems = append(section.elems, &se)
 		case '/':
 			name := strings.TrimSpace(tag[1:])
 			if name != section.name {
 				return parseError{tmpl.curline, "interleaved closing tag: " + name}
 			} else {
+				section.raw = tmpl.data[rawStart:openTagStart]
+				tmpl.stripStandaloneLine(section.elems, openTagStart)
 				return nil
 			}
 		case '{':
 			if tag[len(tag)-1] == '}' {
 				//use a raw tag
 				name := strings.TrimSpace(tag[1 : len(tag)-1])
-				section.elems = append(section.elems, &varElement{name, true})
+				section.elems = append(section.elems, &varElement{name, true, "", "", tmpl.curline})
 			}
 		case '&':
 			name := strings.TrimSpace(tag[1:len(tag)])
-			section.elems = append(section.elems, &varElement{name, true})
+			section.elems = append(section.elems, &varElement{name, true, "", "", tmpl.curline})
+		case '=':
+			otag, ctag, derr := parseSetDelimiterTag(tag)
+			if derr != nil {
+				return parseError{tmpl.curline, derr.Error()}
+			}
+			tmpl.stripStandaloneLine(section.elems, openTagStart)
+			tmpl.otag, tmpl.ctag = otag, ctag
+		case '>':
+			name := strings.TrimSpace(tag[1:])
+			indent, standalone := tmpl.stripStandaloneLine(section.elems, openTagStart)
+			if !standalone {
+				indent = ""
+			}
+			section.elems = append(section.elems, &partialElement{name, indent, tmpl.curline})
 		default:
-			section.elems = append(section.elems, &varElement{tag, false})
+			section.elems = append(section.elems, parseVarTag(tag, false, tmpl.curline))
 		}
 	}
 
@@ -186,6 +458,7 @@ func (tmpl *Template) parseSection(section *sectionElement) error {
 
 func (tmpl *Template) parse() error {
 	for {
+		tagStart := tmpl.p
 		text, err := tmpl.readString(tmpl.otag)
 		if err == io.EOF {
 			//put the remaining text in a block
@@ -195,6 +468,7 @@ func (tmpl *Template) parse() error {
 
 		// put text into an item
 		text = text[0 : len(text)-len(tmpl.otag)]
+		openTagStart := tagStart + len(text)
 		tmpl.elems = append(tmpl.elems, text)
 
 		if tmpl.p < len(tmpl.data) && tmpl.data[tmpl.p] == '{' {
@@ -216,21 +490,19 @@ func (tmpl *Template) parse() error {
 		switch tag[0] {
 		case '!':
 			//ignore comment
-			break
+			tmpl.stripStandaloneLine(tmpl.elems, openTagStart)
 		case '#', '^':
 			name := strings.TrimSpace(tag[1:])
+			tmpl.stripStandaloneLine(tmpl.elems, openTagStart)
 
-			if len(tmpl.data) > tmpl.p && tmpl.data[tmpl.p] == '\n' {
-				tmpl.p += 1
-			} else if len(tmpl.data) > tmpl.p+1 && tmpl.data[tmpl.p] == '\r' && tmpl.data[tmpl.p+1] == '\n' {
-				tmpl.p += 2
-			}
-
-			se := sectionElement{name, tag[0] == '^', tmpl.curline, []interface{}{}}
+			savedOtag, savedCtag := tmpl.otag, tmpl.ctag
+			se := sectionElement{name, tag[0] == '^', tmpl.curline, []interface{}{}, "", tmpl.otag, tmpl.ctag}
 			err := tmpl.parseSection(&se)
 			if err != nil {
 				return err
 			}
+			// Delimiter changes made inside a section are scoped to that section.
+			tmpl.otag, tmpl.ctag = savedOtag, savedCtag
 			tmpl.elems = append(tmpl.elems, &se)
 		case '/':
 			return parseError{tmpl.curline, "unmatched close tag"}
@@ -238,32 +510,52 @@ func (tmpl *Template) parse() error {
 			//use a raw tag
 			if tag[len(tag)-1] == '}' {
 				name := strings.TrimSpace(tag[1 : len(tag)-1])
-				tmpl.elems = append(tmpl.elems, &varElement{name, true})
+				tmpl.elems = append(tmpl.elems, &varElement{name, true, "", "", tmpl.curline})
 			}
 		case '&':
 			name := strings.TrimSpace(tag[1:len(tag)])
-			tmpl.elems = append(tmpl.elems, &varElement{name, true})
+			tmpl.elems = append(tmpl.elems, &varElement{name, true, "", "", tmpl.curline})
+		case '=':
+			otag, ctag, derr := parseSetDelimiterTag(tag)
+			if derr != nil {
+				return parseError{tmpl.curline, derr.Error()}
+			}
+			tmpl.stripStandaloneLine(tmpl.elems, openTagStart)
+			tmpl.otag, tmpl.ctag = otag, ctag
+		case '>':
+			name := strings.TrimSpace(tag[1:])
+			indent, standalone := tmpl.stripStandaloneLine(tmpl.elems, openTagStart)
+			if !standalone {
+				indent = ""
+			}
+			tmpl.elems = append(tmpl.elems, &partialElement{name, indent, tmpl.curline})
 		default:
-			tmpl.elems = append(tmpl.elems, &varElement{tag, false})
+			tmpl.elems = append(tmpl.elems, parseVarTag(tag, false, tmpl.curline))
 		}
 	}
 
 	return nil
 }
 
-// Evaluate interfaces and pointers looking for a value that can look up the name, via a
-// struct field, method, or map key, and return the result of the lookup.
-func lookup(contextChain []reflect.Value, name string) reflect.Value {
+// lookup resolves name against contextChain, searching each context from the innermost outward,
+// via a struct field, map key, or method call. A struct or map miss falls through to a method
+// call on name (value receiver, then pointer receiver if the context is addressable); methods
+// must have signature func() T or func() (T, error), and an error return aborts the lookup and
+// is returned to the caller.
+func lookup(contextChain []reflect.Value, name string) (result reflect.Value, err error) {
 	// dot notation
 	if name != "." && strings.Contains(name, ".") {
 		parts := strings.SplitN(name, ".", 2)
-		v := lookup(contextChain, parts[0])
+		v, err := lookup(contextChain, parts[0])
+		if err != nil {
+			return reflect.Value{}, err
+		}
 		return lookup([]reflect.Value{v}, parts[1])
 	}
 
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Printf("Panic while looking up %q: %s\n", name, r)
+			result, err = reflect.Value{}, fmt.Errorf("%v", r)
 		}
 	}()
 
@@ -272,29 +564,71 @@ Outer:
 		ctx = reflect.Indirect(ctx)
 		for ctx.IsValid() {
 			if name == "." {
-				return ctx
+				return ctx, nil
 			}
 			switch ctx.Kind() {
 			case reflect.Struct:
-				v := ctx.FieldByName(name)
-				if !v.IsValid() {
-					continue Outer
+				if v := ctx.FieldByName(name); v.IsValid() {
+					return v, nil
 				}
-				return v
 			case reflect.Map:
-				v := ctx.MapIndex(reflect.ValueOf(name))
-				if !v.IsValid() {
-					continue Outer
+				if v := ctx.MapIndex(reflect.ValueOf(name)); v.IsValid() {
+					return v, nil
+				}
+			}
+			if m := methodByName(ctx, name); m.IsValid() {
+				v, err := callLookupMethod(m)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				if v.IsValid() {
+					return v, nil
 				}
-				return v
-			default:
-				continue Outer
 			}
+			continue Outer
 		}
 	}
+	return reflect.Value{}, nil
+}
+
+// methodByName returns ctx's method named name, trying the pointer receiver (via ctx.Addr()) when
+// ctx is addressable and has no matching value-receiver method.
+func methodByName(ctx reflect.Value, name string) reflect.Value {
+	if m := ctx.MethodByName(name); m.IsValid() {
+		return m
+	}
+	if ctx.CanAddr() {
+		return ctx.Addr().MethodByName(name)
+	}
 	return reflect.Value{}
 }
 
+// callLookupMethod invokes an argumentless method found by methodByName. Only func() T and
+// func() (T, error) are supported; anything else is treated as not found.
+func callLookupMethod(m reflect.Value) (reflect.Value, error) {
+	fnType := m.Type()
+	if fnType.NumIn() != 0 {
+		return reflect.Value{}, nil
+	}
+	switch fnType.NumOut() {
+	case 1:
+		return m.Call(nil)[0], nil
+	case 2:
+		if !fnType.Out(1).Implements(errorType) {
+			return reflect.Value{}, nil
+		}
+		results := m.Call(nil)
+		if err, _ := results[1].Interface().(error); err != nil {
+			return reflect.Value{}, err
+		}
+		return results[0], nil
+	default:
+		return reflect.Value{}, nil
+	}
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 func isEmpty(v reflect.Value) bool {
 	if !v.IsValid() || v.Interface() == nil {
 		return true
@@ -329,11 +663,158 @@ loop:
 	return v
 }
 
-func renderSection(section *sectionElement, contextChain []reflect.Value, buf io.Writer) {
-	value := lookup(contextChain, section.name)
+// renderLambdaText re-parses text as a Mustache template fragment and renders it against
+// contextChain, which is what the spec requires of a lambda's return value (and of the
+// string passed to a lambda's render callback).
+// Text that fails to parse is returned as is. bytes.Buffer.Write never fails, so the only error
+// returned is a strict-mode *RenderError from rendering the text.
+func renderLambdaText(text string, contextChain []reflect.Value, rc renderContext) (string, error) {
+	tmpl := Template{text, rc.otag, rc.ctag, 0, 1, []interface{}{}, rc.formatters, rc.loader, rc.strict}
+	if err := tmpl.parse(); err != nil {
+		return text, nil
+	}
+	var buf bytes.Buffer
+	err := tmpl.renderTemplate(contextChain, rc, &buf)
+	return buf.String(), err
+}
+
+// callLambdaVar invokes a variable tag's value when it resolves to a func, per the Mustache
+// lambda spec. Only argumentless funcs with a single return value (e.g. func() interface{}) are
+// supported; anything else is left unresolved.
+func callLambdaVar(fn reflect.Value) reflect.Value {
+	fnType := fn.Type()
+	if fnType.NumIn() != 0 || fnType.NumOut() != 1 {
+		return reflect.Value{}
+	}
+	return fn.Call(nil)[0]
+}
+
+// renderLambdaSection invokes a section whose value is a func, passing it the section's raw,
+// unrendered inner text per the Mustache lambda spec. Supported signatures are
+// func(string) string and func(string, func(string) string) string.
+func renderLambdaSection(section *sectionElement, fn reflect.Value, contextChain []reflect.Value, rc renderContext, buf io.Writer) (err error) {
+	defer func() {
+		if r := recover(); r != nil && rc.strict {
+			err = &RenderError{Line: section.startline, Tag: section.name, Cause: fmt.Errorf("%v", r)}
+		}
+	}()
+
+	fnType := fn.Type()
+	var args []reflect.Value
+	var renderErr error
+	switch fnType.NumIn() {
+	case 1:
+		args = []reflect.Value{reflect.ValueOf(section.raw)}
+	case 2:
+		render := func(text string) string {
+			s, err := renderLambdaText(text, contextChain, rc)
+			if renderErr == nil {
+				renderErr = err
+			}
+			return s
+		}
+		args = []reflect.Value{reflect.ValueOf(section.raw), reflect.ValueOf(render)}
+	default:
+		return nil
+	}
+
+	results := fn.Call(args)
+	if len(results) == 0 {
+		return nil
+	}
+
+	out, ok := results[0].Interface().(string)
+	if !ok {
+		return nil
+	}
+	rendered, err := renderLambdaText(out, contextChain, rc)
+	if renderErr == nil {
+		renderErr = err
+	}
+	if renderErr != nil {
+		return renderErr
+	}
+	if _, err := io.WriteString(buf, rendered); err != nil {
+		return &RenderError{Line: section.startline, Tag: section.name, Cause: err}
+	}
+	return nil
+}
+
+// renderPartial resolves a "{{>name}}" tag through rc.loader, bounding recursion at
+// maxPartialDepth, and reproduces the partial's standalone indentation (if any) on every line it
+// renders.
+func renderPartial(elem *partialElement, contextChain []reflect.Value, rc renderContext, buf io.Writer) error {
+	if rc.depth >= maxPartialDepth {
+		return nil
+	}
+
+	if rc.loader == nil {
+		if rc.strict {
+			return &RenderError{Line: elem.line, Tag: elem.name, Cause: errNoLoader}
+		}
+		return nil
+	}
+
+	sub, err := rc.loader.Load(elem.name)
+	if err != nil || sub == nil {
+		if rc.strict {
+			if err == nil {
+				err = fmt.Errorf("mustache: no partial named %q", elem.name)
+			}
+			return &RenderError{Line: elem.line, Tag: elem.name, Cause: err}
+		}
+		return nil
+	}
+
+	childRC := rc
+	childRC.depth++
+
+	if elem.indent == "" {
+		return sub.renderTemplate(contextChain, childRC, buf)
+	}
+
+	var partialBuf bytes.Buffer
+	if err := sub.renderTemplate(contextChain, childRC, &partialBuf); err != nil {
+		return err
+	}
+	return indentLines(buf, elem.indent, partialBuf.String())
+}
+
+// indentLines writes s to w with indent prepended to every line, blank lines included; nothing is
+// written after a trailing newline.
+func indentLines(w io.Writer, indent string, s string) error {
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line == "" {
+			continue
+		}
+		if _, err := io.WriteString(w, indent); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderSection(section *sectionElement, contextChain []reflect.Value, rc renderContext, buf io.Writer) error {
+	value, lookupErr := lookup(contextChain, section.name)
+	if lookupErr != nil {
+		if rc.strict {
+			return &RenderError{Line: section.startline, Tag: section.name, Cause: lookupErr}
+		}
+		value = reflect.Value{}
+	}
 	var context reflect.Value
 	var contexts = []reflect.Value{}
 
+	if fn := indirect(value); fn.IsValid() && fn.Kind() == reflect.Func && !section.inverted {
+		lambdaRC := rc
+		lambdaRC.otag = section.otag
+		lambdaRC.ctag = section.ctag
+		return renderLambdaSection(section, fn, contextChain, lambdaRC, buf)
+	}
+
 	// guard against empty contextChain
 	if len(contextChain) > 0 {
 		context = contextChain[len(contextChain)-1]
@@ -342,7 +823,13 @@ func renderSection(section *sectionElement, contextChain []reflect.Value, buf io
 	// if the value is nil, check if it's an inverted section
 	isEmpty := isEmpty(value)
 	if isEmpty && !section.inverted || !isEmpty && section.inverted {
-		return
+		if isEmpty && !section.inverted && !value.IsValid() && rc.strict {
+			// A non-inverted section on a name that isn't in the context chain at all is a lookup
+			// miss; {{^name}}...{{/name}} is the idiomatic way to test for absence, so inverted
+			// sections never error here even in strict mode.
+			return &RenderError{Line: section.startline, Tag: section.name, Cause: errNoSuchKey}
+		}
+		return nil
 	} else if !section.inverted {
 		valueInd := indirect(value)
 		switch val := valueInd; val.Kind() {
@@ -369,42 +856,92 @@ func renderSection(section *sectionElement, contextChain []reflect.Value, buf io
 	for _, ctx := range contexts {
 		chain2[0] = ctx
 		for _, elem := range section.elems {
-			renderElement(elem, chain2, buf)
+			if err := renderElement(elem, chain2, rc, buf); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
 }
 
-func renderElement(element interface{}, contextChain []reflect.Value, buf io.Writer) {
+func renderElement(element interface{}, contextChain []reflect.Value, rc renderContext, buf io.Writer) (err error) {
 	switch elem := element.(type) {
 	case string:
-		io.WriteString(buf, elem)
+		_, err = io.WriteString(buf, elem)
+		return err
 	case *varElement:
 		defer func() {
-			if r := recover(); r != nil {
-				fmt.Printf("Panic while looking up %q: %s\n", elem.name, r)
+			if r := recover(); r != nil && rc.strict {
+				err = &RenderError{Line: elem.line, Tag: elem.name, Cause: fmt.Errorf("%v", r)}
 			}
 		}()
-		val := lookup(contextChain, elem.name)
+		val, lookupErr := lookup(contextChain, elem.name)
+		if lookupErr != nil {
+			if rc.strict {
+				return &RenderError{Line: elem.line, Tag: elem.name, Cause: lookupErr}
+			}
+			val = reflect.Value{}
+		}
 
-		if val.IsValid() {
-			if elem.raw {
-				fmt.Fprint(buf, val.Interface())
-			} else {
-				s := fmt.Sprint(val.Interface())
-				htmlEscape(buf, []byte(s))
+		if fn := indirect(val); fn.IsValid() && fn.Kind() == reflect.Func {
+			val = callLambdaVar(fn)
+		}
+
+		if !val.IsValid() {
+			if rc.strict {
+				return &RenderError{Line: elem.line, Tag: elem.name, Cause: errNoSuchKey}
+			}
+			return nil
+		}
+
+		if elem.formatter != "" {
+			if f, ok := rc.formatters[elem.formatter]; ok {
+				f(buf, val.Interface(), elem.arg)
+				return nil
+			}
+		}
+		if elem.raw {
+			if _, werr := fmt.Fprint(buf, val.Interface()); werr != nil {
+				return &RenderError{Line: elem.line, Tag: elem.name, Cause: werr}
+			}
+		} else {
+			s := fmt.Sprint(val.Interface())
+			if werr := htmlEscape(buf, []byte(s)); werr != nil {
+				return &RenderError{Line: elem.line, Tag: elem.name, Cause: werr}
 			}
 		}
+		return nil
 	case *sectionElement:
-		renderSection(elem, contextChain, buf)
+		return renderSection(elem, contextChain, rc, buf)
+	case *partialElement:
+		return renderPartial(elem, contextChain, rc, buf)
 	case *Template:
-		elem.renderTemplate(contextChain, buf)
+		return elem.renderTemplate(contextChain, rc, buf)
 	}
+	return nil
 }
 
-func (tmpl *Template) renderTemplate(contextChain []reflect.Value, buf io.Writer) {
+func (tmpl *Template) renderTemplate(contextChain []reflect.Value, rc renderContext, buf io.Writer) error {
 	for _, elem := range tmpl.elems {
-		renderElement(elem, contextChain, buf)
+		if err := renderElement(elem, contextChain, rc, buf); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// Formatters registers a FormatterMap for use by "{{name|fmt}}" tags and returns tmpl so calls
+// can be chained onto ParseString/ParseFile.
+func (tmpl *Template) Formatters(formatters FormatterMap) *Template {
+	tmpl.formatters = formatters
+	return tmpl
+}
+
+// Loader registers a Loader used to resolve "{{>name}}" partials and returns tmpl so calls can be
+// chained onto ParseString/ParseFile.
+func (tmpl *Template) Loader(loader Loader) *Template {
+	tmpl.loader = loader
+	return tmpl
 }
 
 func (tmpl *Template) Render(context ...interface{}) string {
@@ -414,12 +951,55 @@ func (tmpl *Template) Render(context ...interface{}) string {
 		val := reflect.ValueOf(c)
 		contextChain = append(contextChain, val)
 	}
-	tmpl.renderTemplate(contextChain, &buf)
+	rc := renderContext{formatters: tmpl.formatters, loader: tmpl.loader, otag: tmpl.otag, ctag: tmpl.ctag}
+	// Best-effort: errors are discarded here, same as they were swallowed before RenderTo existed.
+	// Use RenderTo to observe them.
+	_ = tmpl.renderTemplate(contextChain, rc, &buf)
 	return buf.String()
 }
 
+// RenderWithLoader renders tmpl like Render, but resolves "{{>name}}" partials through loader
+// instead of any loader set via Loader/ParseStringWithLoader; loader is used unconditionally, even
+// if tmpl already has one of its own.
+func (tmpl *Template) RenderWithLoader(loader Loader, context ...interface{}) string {
+	var buf bytes.Buffer
+	var contextChain []reflect.Value
+	for _, c := range context {
+		val := reflect.ValueOf(c)
+		contextChain = append(contextChain, val)
+	}
+	rc := renderContext{formatters: tmpl.formatters, loader: loader, otag: tmpl.otag, ctag: tmpl.ctag}
+	_ = tmpl.renderTemplate(contextChain, rc, &buf)
+	return buf.String()
+}
+
+// RenderTo streams tmpl's output directly to w instead of materializing it as a string, and
+// returns the first error encountered — either a write error from w itself (e.g. a broken HTTP
+// response) or a *RenderError identifying the tag and line that failed.
+func (tmpl *Template) RenderTo(w io.Writer, context ...interface{}) error {
+	var contextChain []reflect.Value
+	for _, c := range context {
+		contextChain = append(contextChain, reflect.ValueOf(c))
+	}
+	rc := renderContext{formatters: tmpl.formatters, loader: tmpl.loader, strict: tmpl.Strict, otag: tmpl.otag, ctag: tmpl.ctag}
+	return tmpl.renderTemplate(contextChain, rc, w)
+}
+
 func ParseString(data string) (*Template, error) {
-	tmpl := Template{data, "{{", "}}", 0, 1, []interface{}{}}
+	tmpl := Template{data, "{{", "}}", 0, 1, []interface{}{}, nil, nil, false}
+	err := tmpl.parse()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tmpl, err
+}
+
+// ParseStringWithFormatters is like ParseString but registers formatters for use by
+// "{{name|fmt}}" tags.
+func ParseStringWithFormatters(data string, formatters FormatterMap) (*Template, error) {
+	tmpl := Template{data, "{{", "}}", 0, 1, []interface{}{}, formatters, nil, false}
 	err := tmpl.parse()
 
 	if err != nil {
@@ -429,6 +1009,41 @@ func ParseString(data string) (*Template, error) {
 	return &tmpl, err
 }
 
+// ParseStringWithLoader is like ParseString but registers a Loader used to resolve "{{>name}}"
+// partials.
+func ParseStringWithLoader(data string, loader Loader) (*Template, error) {
+	tmpl := Template{data, "{{", "}}", 0, 1, []interface{}{}, nil, loader, false}
+	err := tmpl.parse()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tmpl, err
+}
+
+// ParseStringStrict is like ParseString but sets Strict, so RenderTo reports lookup misses and
+// recovered panics as a *RenderError instead of silently rendering nothing.
+func ParseStringStrict(data string) (*Template, error) {
+	tmpl := Template{data, "{{", "}}", 0, 1, []interface{}{}, nil, nil, true}
+	err := tmpl.parse()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tmpl, err
+}
+
+// ParseFile reads filename and parses its contents as a Mustache template.
+func ParseFile(filename string) (*Template, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseString(string(data))
+}
+
 func Render(data string, context ...interface{}) string {
 	tmpl, err := ParseString(data)
 	if err != nil {
@@ -436,3 +1051,13 @@ func Render(data string, context ...interface{}) string {
 	}
 	return tmpl.Render(context...)
 }
+
+// RenderTo parses data and streams its rendered output to w, returning any error encountered
+// during parsing or rendering. See Template.RenderTo.
+func RenderTo(w io.Writer, data string, context ...interface{}) error {
+	tmpl, err := ParseString(data)
+	if err != nil {
+		return err
+	}
+	return tmpl.RenderTo(w, context...)
+}